@@ -0,0 +1,63 @@
+package vfsgen
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec identifies a compression codec used to encode a file's contents in
+// the generated output.
+type Codec byte
+
+const (
+	// CodecGzip compresses file contents using gzip (DEFLATE). It's the
+	// default codec, preserving vfsgen's historical behavior.
+	CodecGzip Codec = iota
+	// CodecNone stores file contents uncompressed.
+	CodecNone
+	// CodecZstd compresses file contents using zstd, via
+	// github.com/klauspost/compress/zstd.
+	CodecZstd
+	// CodecBrotli compresses file contents using brotli, via
+	// github.com/andybalholm/brotli.
+	CodecBrotli
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecGzip:
+		return "gzip"
+	case CodecNone:
+		return "none"
+	case CodecZstd:
+		return "zstd"
+	case CodecBrotli:
+		return "brotli"
+	default:
+		return fmt.Sprintf("Codec(%d)", byte(c))
+	}
+}
+
+// CodecSelector picks the compression codec to use for a single file, based
+// on its path and its detected Content-Type. It's consulted once per file
+// during Generate; see Options.CompressionFunc.
+type CodecSelector func(path string, contentType string) Codec
+
+// newEncoder returns a WriteCloser that compresses into w using c.
+// Callers must Close it to flush any buffered output.
+func newEncoder(c Codec, w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case CodecGzip:
+		return gzip.NewWriter(w), nil
+	case CodecZstd:
+		return zstd.NewWriter(w)
+	case CodecBrotli:
+		return brotli.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("vfsgen: unsupported codec %v", c)
+	}
+}
@@ -0,0 +1,93 @@
+package vfsgen
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestGenerateIOFSFamily drives the generated FS's IOFS() view through
+// fs.ReadFile, fs.Stat, fs.Glob, and fs.Sub (Options.EmitFSInterface
+// defaults to true), confirming the io/fs.FS family works end to end
+// against compiled, generated output.
+func TestGenerateIOFSFamily(t *testing.T) {
+	inputDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(inputDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rootContent := "root file content"
+	subContent := "sub file content"
+	if err := os.WriteFile(filepath.Join(inputDir, "root.txt"), []byte(rootContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "sub", "leaf.txt"), []byte(subContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	moduleDir := t.TempDir()
+	opt := Options{
+		Filename:     filepath.Join(moduleDir, "assets_vfsdata.go"),
+		PackageName:  "main",
+		VariableName: "Assets",
+	}
+	if err := Generate(http.Dir(inputDir), opt); err != nil {
+		t.Fatal(err)
+	}
+
+	harness := `package main
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+func main() {
+	iofs := Assets.IOFS()
+
+	content, err := fs.ReadFile(iofs, "root.txt")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("readfile:%s\n", content)
+
+	info, err := fs.Stat(iofs, "sub/leaf.txt")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("stat-size:", info.Size())
+	fmt.Println("stat-isdir:", info.IsDir())
+
+	matches, err := fs.Glob(iofs, "*.txt")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("glob:", matches)
+
+	sub, err := fs.Sub(iofs, "sub")
+	if err != nil {
+		panic(err)
+	}
+	subFileContent, err := fs.ReadFile(sub, "leaf.txt")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("sub-readfile:%s\n", subFileContent)
+}
+`
+	out := runGeneratedHarness(t, moduleDir, harness)
+
+	for _, want := range []string{
+		"readfile:" + rootContent,
+		"stat-size: " + strconv.Itoa(len(subContent)),
+		"stat-isdir: false",
+		"glob: [root.txt]",
+		"sub-readfile:" + subContent,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected generated program output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
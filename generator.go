@@ -2,7 +2,8 @@ package vfsgen
 
 import (
 	"bytes"
-	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -10,8 +11,10 @@ import (
 	"net/http"
 	"os"
 	pathpkg "path"
+	"runtime"
 	"sort"
 	"strconv"
+	"sync"
 	"text/template"
 	"time"
 
@@ -23,29 +26,56 @@ import (
 func Generate(input http.FileSystem, opt Options) error {
 	opt.fillMissing()
 
+	if opt.OutputFormat == Zip {
+		return generateZip(input, opt)
+	}
+
 	// add the prefix to the template
 	t = t.Funcs(template.FuncMap{"prefix": func() string { return opt.Prefix }})
 
+	// The body (file and directory entries) is generated first, since the
+	// header needs to know which codecs ended up being used before it can
+	// write its import block.
+	body := new(bytes.Buffer)
+
+	var manifest toc
+	err := findAndWriteFiles(body, input, &manifest, &opt)
+	if err != nil {
+		return err
+	}
+
 	// Use an in-memory buffer to generate the entire output.
 	buf := new(bytes.Buffer)
 
-	err := t.ExecuteTemplate(buf, "Header", opt)
+	data := genData{
+		Options:         opt,
+		toc:             manifest,
+		EmitFSInterface: opt.emitFSInterface(),
+	}
+
+	err = t.ExecuteTemplate(buf, "Header", data)
 	if err != nil {
 		return err
 	}
 
-	var toc toc
-	err = findAndWriteFiles(buf, input, &toc)
+	_, err = buf.Write(body.Bytes())
 	if err != nil {
 		return err
 	}
 
-	err = t.ExecuteTemplate(buf, "DirEntries", toc.dirs)
+	err = t.ExecuteTemplate(buf, "DirEntries", manifest.dirs)
 	if err != nil {
 		return err
 	}
 
-	err = t.ExecuteTemplate(buf, "Trailer", toc)
+	for _, blob := range manifest.blobs {
+		_, err = buf.Write(blob)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = t.ExecuteTemplate(buf, "Trailer", data)
 	if err != nil {
 		return err
 	}
@@ -57,10 +87,37 @@ func Generate(input http.FileSystem, opt Options) error {
 }
 
 type toc struct {
-	dirs []*dirInfo
+	dirs  []*dirInfo
+	blobs [][]byte // Rendered `var {{prefix}}۰blobN = []byte("...")` blocks, in index order; populated only when Options.Deduplicate is set.
 
 	HasCompressedFile bool // There's at least one compressedFile.
 	HasFile           bool // There's at least one uncompressed file.
+
+	HasGzip   bool // There's at least one gzip compressed file.
+	HasZstd   bool // There's at least one zstd compressed file.
+	HasBrotli bool // There's at least one brotli compressed file.
+}
+
+// genData is the combined data passed to the Header and Trailer templates.
+type genData struct {
+	Options
+	toc
+
+	EmitFSInterface bool // Whether to emit the io/fs.FS family of methods.
+}
+
+// noteCodec records that a file was compressed using c, so the header can
+// import the right decoder package and the trailer can emit the right
+// decode case.
+func (t *toc) noteCodec(c Codec) {
+	switch c {
+	case CodecGzip:
+		t.HasGzip = true
+	case CodecZstd:
+		t.HasZstd = true
+	case CodecBrotli:
+		t.HasBrotli = true
+	}
 }
 
 // fileInfo is a definition of a file.
@@ -69,6 +126,10 @@ type fileInfo struct {
 	Name             string
 	ModTime          time.Time
 	UncompressedSize int64
+	ContentType      string
+	ETag             string // Hex-encoded SHA-256 of the file's uncompressed content.
+	Codec            Codec
+	BlobIndex        int // Index of the {{prefix}}۰blobN variable holding this file's stored content; only meaningful when Options.Deduplicate is set.
 }
 
 // dirInfo is a definition of a directory.
@@ -79,77 +140,394 @@ type dirInfo struct {
 	Entries []string
 }
 
+// walkItem is either a file or a directory discovered while walking the
+// input filesystem, in the order findAndWriteFiles must emit it in.
+type walkItem struct {
+	path string
+	fi   os.FileInfo
+	dir  *dirInfo // non-nil for directories
+}
+
+// fileResult is the output of compressing a single file, produced by a
+// worker and later written into the output buffer in path order.
+type fileResult struct {
+	block      []byte
+	compressed bool
+	codec      Codec
+	err        error
+}
+
 // findAndWriteFiles recursively finds all the file paths in the given directory tree.
-// They are added to the given map as keys. Values will be safe function names
-// for each file, which will be used when generating the output code.
-func findAndWriteFiles(buf *bytes.Buffer, fs http.FileSystem, toc *toc) error {
+// Files are compressed concurrently across a pool of workers (sized by
+// Options.Concurrency, defaulting to runtime.NumCPU()); the results are then
+// written into buf in the same deterministic path order the filesystem was
+// walked in, so the output is reproducible regardless of which worker
+// finishes first.
+func findAndWriteFiles(buf *bytes.Buffer, fs http.FileSystem, toc *toc, opt *Options) error {
+	items, err := walkInputFiles(fs, toc)
+	if err != nil {
+		return err
+	}
+
+	if opt.Deduplicate {
+		return writeFilesDeduped(buf, fs, items, toc, opt)
+	}
+	return writeFilesPlain(buf, fs, items, toc, opt)
+}
+
+// walkInputFiles walks fs in path order, recording toc.dirs as it goes and
+// returning every file and directory found, in walk order.
+func walkInputFiles(fs http.FileSystem, toc *toc) ([]walkItem, error) {
+	var items []walkItem
 	walkFn := func(path string, fi os.FileInfo, r io.ReadSeeker, err error) error {
 		if err != nil {
 			// Consider all errors reading the input filesystem as fatal.
 			return err
 		}
 
-		switch fi.IsDir() {
-		case false:
-			file := &fileInfo{
-				Path:             path,
-				Name:             pathpkg.Base(path),
-				ModTime:          fi.ModTime().UTC(),
-				UncompressedSize: fi.Size(),
-			}
+		if !fi.IsDir() {
+			items = append(items, walkItem{path: path, fi: fi})
+			return nil
+		}
 
-			marker := buf.Len()
+		entries, err := readDirPaths(fs, path)
+		if err != nil {
+			return err
+		}
 
-			// Write CompressedFileInfo.
-			err = writeCompressedFileInfo(buf, file, r)
-			switch err {
-			default:
-				return err
-			case nil:
-				toc.HasCompressedFile = true
-			// If compressed file is not smaller than original, revert and write original file.
-			case errCompressedNotSmaller:
-				_, err = r.Seek(0, io.SeekStart)
-				if err != nil {
-					return err
-				}
-
-				buf.Truncate(marker)
-
-				// Write FileInfo.
-				err = writeFileInfo(buf, file, r)
-				if err != nil {
-					return err
-				}
-				toc.HasFile = true
+		dir := &dirInfo{
+			Path:    path,
+			Name:    pathpkg.Base(path),
+			ModTime: fi.ModTime().UTC(),
+			Entries: entries,
+		}
+		toc.dirs = append(toc.dirs, dir)
+		items = append(items, walkItem{path: path, fi: fi, dir: dir})
+		return nil
+	}
+	err := vfsutil.WalkFiles(fs, "/", walkFn)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// writeFilesPlain compresses each file into its own scratch buffer and
+// writes it into buf, embedding its content inline.
+func writeFilesPlain(buf *bytes.Buffer, fs http.FileSystem, items []walkItem, toc *toc, opt *Options) error {
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]fileResult, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = compressFile(fs, items[i].path, items[i].fi, opt)
 			}
-		case true:
-			entries, err := readDirPaths(fs, path)
+		}()
+	}
+	for i, item := range items {
+		if item.dir != nil {
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, item := range items {
+		if item.dir != nil {
+			err := t.ExecuteTemplate(buf, "DirInfo", item.dir)
 			if err != nil {
 				return err
 			}
+			continue
+		}
+
+		res := results[i]
+		if res.err != nil {
+			return res.err
+		}
+		buf.Write(res.block)
+		if res.compressed {
+			toc.HasCompressedFile = true
+			toc.noteCodec(res.codec)
+		} else {
+			toc.HasFile = true
+		}
+	}
+
+	return nil
+}
+
+// compressFile opens path fresh from fs and writes its FileInfo or
+// CompressedFileInfo template block into a scratch buffer of its own, so it
+// can run concurrently with other files without sharing state.
+func compressFile(fs http.FileSystem, path string, fi os.FileInfo, opt *Options) fileResult {
+	rf, err := fs.Open(path)
+	if err != nil {
+		return fileResult{err: err}
+	}
+	defer rf.Close()
+
+	file := &fileInfo{
+		Path:             path,
+		Name:             pathpkg.Base(path),
+		ModTime:          fi.ModTime().UTC(),
+		UncompressedSize: fi.Size(),
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(rf, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fileResult{err: err}
+	}
+	file.ContentType = http.DetectContentType(sniff[:n])
+	_, err = rf.Seek(0, io.SeekStart)
+	if err != nil {
+		return fileResult{err: err}
+	}
+	file.Codec = opt.codecFor(file.Path, file.ContentType)
+
+	etag, err := etagFor(fs, path)
+	if err != nil {
+		return fileResult{err: err}
+	}
+	file.ETag = etag
+
+	scratch := new(bytes.Buffer)
+
+	if file.Codec == CodecNone {
+		if err := writeFileInfo(scratch, file, rf); err != nil {
+			return fileResult{err: err}
+		}
+		return fileResult{block: scratch.Bytes()}
+	}
+
+	err = writeCompressedFileInfo(scratch, file, rf)
+	switch err {
+	default:
+		return fileResult{err: err}
+	case nil:
+		return fileResult{block: scratch.Bytes(), compressed: true, codec: file.Codec}
+	// If compressed file is not smaller than original, revert and write original file.
+	case errCompressedNotSmaller:
+		_, err = rf.Seek(0, io.SeekStart)
+		if err != nil {
+			return fileResult{err: err}
+		}
+		scratch.Reset()
+		if err := writeFileInfo(scratch, file, rf); err != nil {
+			return fileResult{err: err}
+		}
+		return fileResult{block: scratch.Bytes()}
+	}
+}
+
+// etagFor opens a fresh handle on path and returns the hex-encoded SHA-256
+// of its full, uncompressed content, for use as a precomputed strong ETag.
+// It reads the file independently of any compression in progress on another
+// handle, so it can run concurrently with compressFile's own streaming read.
+func etagFor(fs http.FileSystem, path string) (string, error) {
+	rf, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer rf.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dedupResult is the output of compressing a single file under
+// Options.Deduplicate: the content actually destined for storage (compressed
+// or, on fallback, raw), ready to be deduplicated against other files' by
+// uncompressed digest (etag) and codec.
+type dedupResult struct {
+	content     []byte
+	compressed  bool
+	codec       Codec
+	etag        string // Hex-encoded SHA-256 of the original, uncompressed content.
+	contentType string
+	err         error
+}
+
+// compressFileRaw opens path fresh from fs, compresses its full contents
+// using the codec selected by opt, and returns the bytes that should end up
+// stored in the generated code (falling back to the original, uncompressed
+// bytes if compression didn't help). Unlike compressFile, it returns the raw
+// stored bytes rather than a rendered template block, so the caller can
+// content-address them before deciding whether to render a new blob.
+func compressFileRaw(fs http.FileSystem, path string, opt *Options) dedupResult {
+	rf, err := fs.Open(path)
+	if err != nil {
+		return dedupResult{err: err}
+	}
+	defer rf.Close()
+
+	content, err := ioutil.ReadAll(rf)
+	if err != nil {
+		return dedupResult{err: err}
+	}
+
+	digest := sha256.Sum256(content)
+	etag := hex.EncodeToString(digest[:])
+	contentType := http.DetectContentType(content)
+
+	codec := opt.codecFor(path, contentType)
+	if codec == CodecNone {
+		return dedupResult{content: content, codec: CodecNone, etag: etag, contentType: contentType}
+	}
+
+	scratch := new(bytes.Buffer)
+	ew, err := newEncoder(codec, scratch)
+	if err != nil {
+		return dedupResult{err: err}
+	}
+	if _, err := ew.Write(content); err != nil {
+		return dedupResult{err: err}
+	}
+	if err := ew.Close(); err != nil {
+		return dedupResult{err: err}
+	}
+	if scratch.Len() >= len(content) {
+		// Compressed form isn't smaller than the original; store it raw.
+		return dedupResult{content: content, codec: CodecNone, etag: etag, contentType: contentType}
+	}
+	return dedupResult{content: scratch.Bytes(), compressed: true, codec: codec, etag: etag, contentType: contentType}
+}
+
+// writeFilesDeduped is the Options.Deduplicate variant of writeFilesPlain: it
+// also compresses files concurrently, but instead of embedding every file's
+// content inline, it content-addresses each file by its uncompressed digest
+// (its ETag) plus the codec applied to it, and writes each distinct blob
+// exactly once, into toc.blobs, as a {{prefix}}۰blobN package-level
+// variable. Map entries for files sharing a blob all reference the same
+// variable; files with identical uncompressed content but different codecs
+// get distinct blobs, since their stored bytes differ. Assignment of
+// canonical vs. shared happens in a single serial pass over items in path
+// order, so the choice of which occurrence is canonical (and hence which
+// blob indices are assigned) is deterministic regardless of worker
+// completion order.
+func writeFilesDeduped(buf *bytes.Buffer, fs http.FileSystem, items []walkItem, toc *toc, opt *Options) error {
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]dedupResult, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = compressFileRaw(fs, items[i].path, opt)
+			}
+		}()
+	}
+	for i, item := range items {
+		if item.dir != nil {
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// blobKey dedups on the file's uncompressed content digest (the file's
+	// ETag) together with the codec applied to it: two files with identical
+	// uncompressed bytes only share a blob if opt.codecFor also picked the
+	// same codec for both, so their stored bytes are guaranteed identical.
+	type blobKey struct {
+		etag  string
+		codec Codec
+	}
+	blobIndexByDigest := make(map[blobKey]int)
 
-			dir := &dirInfo{
-				Path:    path,
-				Name:    pathpkg.Base(path),
-				ModTime: fi.ModTime().UTC(),
-				Entries: entries,
+	for i, item := range items {
+		if item.dir != nil {
+			if err := t.ExecuteTemplate(buf, "DirInfo", item.dir); err != nil {
+				return err
 			}
+			continue
+		}
 
-			toc.dirs = append(toc.dirs, dir)
+		res := results[i]
+		if res.err != nil {
+			return res.err
+		}
 
-			// Write DirInfo.
-			err = t.ExecuteTemplate(buf, "DirInfo", dir)
+		key := blobKey{etag: res.etag, codec: res.codec}
+		blobIndex, ok := blobIndexByDigest[key]
+		if !ok {
+			blobIndex = len(toc.blobs)
+			blobIndexByDigest[key] = blobIndex
+			blob, err := renderBlob(blobIndex, res.content)
 			if err != nil {
 				return err
 			}
+			toc.blobs = append(toc.blobs, blob)
 		}
 
-		return nil
+		file := &fileInfo{
+			Path:             item.path,
+			Name:             pathpkg.Base(item.path),
+			ModTime:          item.fi.ModTime().UTC(),
+			UncompressedSize: item.fi.Size(),
+			Codec:            res.codec,
+			ETag:             res.etag,
+			ContentType:      res.contentType,
+			BlobIndex:        blobIndex,
+		}
+
+		if res.compressed {
+			if err := t.ExecuteTemplate(buf, "CompressedFileInfo-Dedup", file); err != nil {
+				return err
+			}
+			toc.HasCompressedFile = true
+			toc.noteCodec(res.codec)
+		} else {
+			if err := t.ExecuteTemplate(buf, "FileInfo-Dedup", file); err != nil {
+				return err
+			}
+			toc.HasFile = true
+		}
 	}
 
-	err := vfsutil.WalkFiles(fs, "/", walkFn)
-	return err
+	return nil
+}
+
+// blobData is the data passed to the Blob-Before/Blob-After templates.
+type blobData struct {
+	Index int
+}
+
+// renderBlob renders the {{prefix}}۰blobN = []byte("...") declaration for a
+// single deduplicated blob.
+func renderBlob(index int, content []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := t.ExecuteTemplate(buf, "Blob-Before", blobData{Index: index}); err != nil {
+		return nil, err
+	}
+	sw := &stringWriter{Writer: buf}
+	if _, err := sw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := t.ExecuteTemplate(buf, "Blob-After", blobData{Index: index}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // readDirPaths reads the directory named by dirname and returns
@@ -175,12 +553,15 @@ func writeCompressedFileInfo(w io.Writer, file *fileInfo, r io.Reader) error {
 		return err
 	}
 	sw := &stringWriter{Writer: w}
-	gw := gzip.NewWriter(sw)
-	_, err = io.Copy(gw, r)
+	ew, err := newEncoder(file.Codec, sw)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(ew, r)
 	if err != nil {
 		return err
 	}
-	err = gw.Close()
+	err = ew.Close()
 	if err != nil {
 		return err
 	}
@@ -211,6 +592,16 @@ func writeFileInfo(w io.Writer, file *fileInfo, r io.Reader) error {
 var t = template.Must(template.New("").Funcs(template.FuncMap{
 	"prefix": func() string { return "vfsgen" }, // overridden later
 	"quote":  strconv.Quote,
+	"codecConst": func(c Codec) string {
+		switch c {
+		case CodecZstd:
+			return "CodecZstd"
+		case CodecBrotli:
+			return "CodecBrotli"
+		default:
+			return "CodecGzip"
+		}
+	},
 	"comment": func(s string) (string, error) {
 		var buf bytes.Buffer
 		cw := &commentWriter{W: &buf}
@@ -237,10 +628,18 @@ import (
 	"os"
 	pathpkg "path"
 	"time"
-)
+{{if .HasCompressedFile}}
+	"strings"
+{{end}}{{if .HasZstd}}
+	"github.com/klauspost/compress/zstd"
+{{end}}{{if .HasBrotli}}
+	"github.com/andybalholm/brotli"
+{{end}}{{if .EmitFSInterface}}
+	"io/fs"
+{{end}})
 
 {{comment .VariableComment}}
-var {{.VariableName}} = func() http.FileSystem {
+var {{.VariableName}} = func() {{prefix}}۰FS {
 	fs := {{prefix}}۰FS{
 {{end}}
 
@@ -250,6 +649,9 @@ var {{.VariableName}} = func() http.FileSystem {
 			name:             {{quote .Name}},
 			modTime:          {{template "Time" .ModTime}},
 			uncompressedSize: {{.UncompressedSize}},
+			codec:            {{prefix}}۰{{codecConst .Codec}},
+			etag:             {{quote .ETag}},
+			contentType:      {{quote .ContentType}},
 {{/* This blank line separating compressedContent is neccessary to prevent potential gofmt issues. See issue #19. */}}
 			compressedContent: []byte("{{end}}{{define "CompressedFileInfo-After"}}"),
 		},
@@ -258,8 +660,10 @@ var {{.VariableName}} = func() http.FileSystem {
 
 
 {{define "FileInfo-Before"}}		{{quote .Path}}: &{{prefix}}۰FileInfo{
-			name:    {{quote .Name}},
-			modTime: {{template "Time" .ModTime}},
+			name:        {{quote .Name}},
+			modTime:     {{template "Time" .ModTime}},
+			etag:        {{quote .ETag}},
+			contentType: {{quote .ContentType}},
 			content: []byte("{{end}}{{define "FileInfo-After"}}"),
 		},
 {{end}}
@@ -274,6 +678,35 @@ var {{.VariableName}} = func() http.FileSystem {
 
 
 
+{{define "CompressedFileInfo-Dedup"}}		{{quote .Path}}: &{{prefix}}۰CompressedFileInfo{
+			name:              {{quote .Name}},
+			modTime:           {{template "Time" .ModTime}},
+			uncompressedSize:  {{.UncompressedSize}},
+			codec:             {{prefix}}۰{{codecConst .Codec}},
+			etag:              {{quote .ETag}},
+			contentType:       {{quote .ContentType}},
+			compressedContent: {{prefix}}۰blob{{.BlobIndex}},
+		},
+{{end}}
+
+
+
+{{define "FileInfo-Dedup"}}		{{quote .Path}}: &{{prefix}}۰FileInfo{
+			name:        {{quote .Name}},
+			modTime:     {{template "Time" .ModTime}},
+			etag:        {{quote .ETag}},
+			contentType: {{quote .ContentType}},
+			content:     {{prefix}}۰blob{{.BlobIndex}},
+		},
+{{end}}
+
+
+
+{{define "Blob-Before"}}var {{prefix}}۰blob{{.Index}} = []byte("{{end}}{{define "Blob-After"}}")
+{{end}}
+
+
+
 {{define "DirEntries"}}	}
 {{range .}}{{if .Entries}}	fs[{{quote .Path}}].(*{{prefix}}۰DirInfo).entries = []os.FileInfo{{"{"}}{{range .Entries}}
 		fs[{{quote .}}].(os.FileInfo),{{end}}
@@ -297,10 +730,10 @@ func (fs {{prefix}}۰FS) Open(path string) (http.File, error) {
 
 	switch f := f.(type) {{"{"}}{{if .HasCompressedFile}}
 	case *{{prefix}}۰CompressedFileInfo:
-		gr, err := gzip.NewReader(bytes.NewReader(f.compressedContent))
+		gr, err := {{prefix}}۰newReader(f.codec, f.compressedContent)
 		if err != nil {
-			// This should never happen because we generate the gzip bytes such that they are always valid.
-			panic("unexpected error reading own gzip compressed bytes: " + err.Error())
+			// This should never happen because we generate the compressed bytes such that they are always valid.
+			panic("unexpected error constructing decompressor for own compressed bytes: " + err.Error())
 		}
 		return &{{prefix}}۰CompressedFile{
 			{{prefix}}۰CompressedFileInfo: f,
@@ -320,13 +753,189 @@ func (fs {{prefix}}۰FS) Open(path string) (http.File, error) {
 		panic(fmt.Sprintf("unexpected type %T", f))
 	}
 }
+
+// HTTPHandler returns an http.Handler that serves the files in fs directly,
+// using each file's precomputed ETag and Content-Type. When the client sent
+// "Accept-Encoding: gzip" and a file is stored gzip-compressed, its
+// compressed bytes are written straight through without re-compressing or
+// decompressing, and a "Vary: Accept-Encoding" response header is set so
+// shared caches don't serve the compressed form to clients that didn't ask
+// for it. If-None-Match and If-Modified-Since are honored with a 304
+// response. It doesn't serve directory listings; use http.FileServer(fs)
+// for that.
+func (fs {{prefix}}۰FS) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := pathpkg.Clean("/" + r.URL.Path)
+		f, ok := fs[path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		hfi, ok := f.(interface {
+			ETag() string
+			ContentType() string
+			ModTime() time.Time
+		})
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag, contentType, modTime := hfi.ETag(), hfi.ContentType(), hfi.ModTime()
+
+		quotedETag := fmt.Sprintf("%q", etag)
+		w.Header().Set("ETag", quotedETag)
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+
+		if inm := r.Header.Get("If-None-Match"); inm != "" {
+			if inm == quotedETag || inm == "*" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		} else if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+			if t, err := http.ParseTime(ims); err == nil && !modTime.Truncate(time.Second).After(t) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
 {{if .HasCompressedFile}}
-// {{prefix}}۰CompressedFileInfo is a static definition of a gzip compressed file.
+		if cf, ok := f.(*{{prefix}}۰CompressedFileInfo); ok && cf.codec == {{prefix}}۰CodecGzip {
+			w.Header().Set("Vary", "Accept-Encoding")
+			if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Header().Set("Content-Length", fmt.Sprint(len(cf.compressedContent)))
+				w.Write(cf.compressedContent)
+				return
+			}
+		}
+{{end}}
+		hf, err := fs.Open(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer hf.Close()
+		io.Copy(w, hf)
+	})
+}
+{{if .EmitFSInterface}}
+// {{prefix}}۰ioFSBase adapts {{prefix}}۰FS to the base fs.FS and fs.ReadDirFS
+// interfaces. It's kept separate from {{prefix}}۰ioFS so that Glob and Sub
+// below can delegate to the fs package's generic implementations without
+// those calling straight back into themselves.
+type {{prefix}}۰ioFSBase struct {
+	fs {{prefix}}۰FS
+}
+
+func (b {{prefix}}۰ioFSBase) Open(name string) (fs.File, error) {
+	hf, err := b.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return hf.(fs.File), nil
+}
+
+func (b {{prefix}}۰ioFSBase) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = pathpkg.Clean("/" + name)
+	e, ok := b.fs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	d, ok := e.(*{{prefix}}۰DirInfo)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+	entries := make([]fs.DirEntry, len(d.entries))
+	for i, fi := range d.entries {
+		entries[i] = fs.FileInfoToDirEntry(fi)
+	}
+	return entries, nil
+}
+
+// {{prefix}}۰ioFS adapts {{prefix}}۰FS to the io/fs.FS family of interfaces
+// (fs.FS, fs.ReadDirFS, fs.ReadFileFS, fs.StatFS, fs.GlobFS, fs.SubFS).
+// {{prefix}}۰FS can't implement fs.FS directly: fs.FS.Open and
+// http.FileSystem.Open share a name but return different types, so the two
+// can't coexist on one type. Use {{prefix}}۰FS.IOFS to get this view.
+type {{prefix}}۰ioFS struct {
+	{{prefix}}۰ioFSBase
+}
+
+func (f {{prefix}}۰ioFS) ReadFile(name string) ([]byte, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (f {{prefix}}۰ioFS) Stat(name string) (fs.FileInfo, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
+}
+
+func (f {{prefix}}۰ioFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(f.{{prefix}}۰ioFSBase, pattern)
+}
+
+func (f {{prefix}}۰ioFS) Sub(dir string) (fs.FS, error) {
+	return fs.Sub(f.{{prefix}}۰ioFSBase, dir)
+}
+
+// IOFS returns an io/fs.FS view of fs, suitable for use with
+// template.ParseFS, http.FS, embed-consuming libraries, and similar. The
+// returned value also implements fs.ReadDirFS, fs.ReadFileFS, fs.StatFS,
+// fs.GlobFS, and fs.SubFS.
+func (fs {{prefix}}۰FS) IOFS() fs.FS {
+	return {{prefix}}۰ioFS{ {{prefix}}۰ioFSBase{fs: fs} }
+}
+{{end}}
+{{if .HasCompressedFile}}
+// Codec identifies the compression codec used to store a compressed file's
+// content.
+type {{prefix}}۰Codec byte
+
+const (
+	{{prefix}}۰CodecGzip {{prefix}}۰Codec = iota
+	{{prefix}}۰CodecZstd
+	{{prefix}}۰CodecBrotli
+)
+
+// {{prefix}}۰newReader returns a decompressor for content, chosen by codec.
+func {{prefix}}۰newReader(codec {{prefix}}۰Codec, content []byte) (io.ReadCloser, error) {
+	switch codec {
+	case {{prefix}}۰CodecGzip:
+		return gzip.NewReader(bytes.NewReader(content))
+{{if .HasZstd}}	case {{prefix}}۰CodecZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+{{end}}{{if .HasBrotli}}	case {{prefix}}۰CodecBrotli:
+		return ioutil.NopCloser(brotli.NewReader(bytes.NewReader(content))), nil
+{{end}}	default:
+		return nil, fmt.Errorf("vfsgen: unknown codec %d", codec)
+	}
+}
+
+// {{prefix}}۰CompressedFileInfo is a static definition of a compressed file.
 type {{prefix}}۰CompressedFileInfo struct {
 	name              string
 	modTime           time.Time
 	compressedContent []byte
 	uncompressedSize  int64
+	codec             {{prefix}}۰Codec
+	etag              string
+	contentType       string
 }
 
 func (f *{{prefix}}۰CompressedFileInfo) Readdir(count int) ([]os.FileInfo, error) {
@@ -338,6 +947,14 @@ func (f *{{prefix}}۰CompressedFileInfo) GzipBytes() []byte {
 	return f.compressedContent
 }
 
+// ETag returns the file's precomputed strong ETag: a hex-encoded SHA-256 of
+// its uncompressed content.
+func (f *{{prefix}}۰CompressedFileInfo) ETag() string { return f.etag }
+
+// ContentType returns the file's Content-Type, detected at generate time via
+// http.DetectContentType.
+func (f *{{prefix}}۰CompressedFileInfo) ContentType() string { return f.contentType }
+
 func (f *{{prefix}}۰CompressedFileInfo) Name() string       { return f.name }
 func (f *{{prefix}}۰CompressedFileInfo) Size() int64        { return f.uncompressedSize }
 func (f *{{prefix}}۰CompressedFileInfo) Mode() os.FileMode  { return 0444 }
@@ -348,7 +965,7 @@ func (f *{{prefix}}۰CompressedFileInfo) Sys() interface{}   { return nil }
 // {{prefix}}۰CompressedFile is an opened compressedFile instance.
 type {{prefix}}۰CompressedFile struct {
 	*{{prefix}}۰CompressedFileInfo
-	gr      *gzip.Reader
+	gr      io.ReadCloser
 	grPos   int64 // Actual gr uncompressed position.
 	seekPos int64 // Seek uncompressed position.
 }
@@ -356,7 +973,11 @@ type {{prefix}}۰CompressedFile struct {
 func (f *{{prefix}}۰CompressedFile) Read(p []byte) (n int, err error) {
 	if f.grPos > f.seekPos {
 		// Rewind to beginning.
-		err = f.gr.Reset(bytes.NewReader(f.compressedContent))
+		err = f.gr.Close()
+		if err != nil {
+			return 0, err
+		}
+		f.gr, err = {{prefix}}۰newReader(f.codec, f.compressedContent)
 		if err != nil {
 			return 0, err
 		}
@@ -398,9 +1019,11 @@ var _ = ioutil.Discard
 {{end}}{{if .HasFile}}
 // {{prefix}}۰FileInfo is a static definition of an uncompressed file (because it's not worth gzip compressing).
 type {{prefix}}۰FileInfo struct {
-	name    string
-	modTime time.Time
-	content []byte
+	name        string
+	modTime     time.Time
+	content     []byte
+	etag        string
+	contentType string
 }
 
 func (f *{{prefix}}۰FileInfo) Readdir(count int) ([]os.FileInfo, error) {
@@ -410,6 +1033,14 @@ func (f *{{prefix}}۰FileInfo) Stat() (os.FileInfo, error) { return f, nil }
 
 func (f *{{prefix}}۰FileInfo) NotWorthGzipCompressing() {}
 
+// ETag returns the file's precomputed strong ETag: a hex-encoded SHA-256 of
+// its content.
+func (f *{{prefix}}۰FileInfo) ETag() string { return f.etag }
+
+// ContentType returns the file's Content-Type, detected at generate time via
+// http.DetectContentType.
+func (f *{{prefix}}۰FileInfo) ContentType() string { return f.contentType }
+
 func (f *{{prefix}}۰FileInfo) Name() string       { return f.name }
 func (f *{{prefix}}۰FileInfo) Size() int64        { return int64(len(f.content)) }
 func (f *{{prefix}}۰FileInfo) Mode() os.FileMode  { return 0444 }
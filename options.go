@@ -0,0 +1,113 @@
+package vfsgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options for vfsgen.Generate.
+type Options struct {
+	// Filename of the generated Go code output file. Defaults to
+	// "{{toLower .VariableName}}_vfsdata.go".
+	Filename string
+
+	// PackageName is the name of the package in the generated code.
+	// Defaults to "main".
+	PackageName string
+
+	// BuildTags are the optional build tags in the generated code.
+	// The default is empty, which means no build tags.
+	BuildTags string
+
+	// VariableName is the name of the variable in the generated code.
+	// Defaults to "assets".
+	VariableName string
+
+	// VariableComment is the comment of the variable in the generated code.
+	// Defaults to "{{.VariableName}} statically implements the virtual
+	// filesystem provided to vfsgen.Generate."
+	VariableComment string
+
+	// Prefix is the identifier prefix used for all unexported types in the
+	// generated code, to avoid collisions when multiple vfsgen-generated
+	// files live in the same package. Defaults to "vfsgen".
+	Prefix string
+
+	// Compression selects the codec used to compress file contents in the
+	// generated code. Defaults to CodecGzip.
+	Compression Codec
+
+	// CompressionFunc, if set, is called once per file to select the codec
+	// used for that specific file, overriding Compression. It's given the
+	// file's path and its detected Content-Type.
+	CompressionFunc CodecSelector
+
+	// Concurrency is the number of files compressed in parallel. Defaults
+	// to runtime.NumCPU() when 0 or negative.
+	Concurrency int
+
+	// EmitFSInterface controls whether the generated FS type also implements
+	// the io/fs.FS family of interfaces (via its IOFS method), in addition
+	// to http.FileSystem. Defaults to true; set to a pointer to false to
+	// opt out.
+	EmitFSInterface *bool
+
+	// OutputFormat selects how the input filesystem is encoded on disk.
+	// Defaults to GoSource.
+	OutputFormat OutputFormat
+
+	// Deduplicate enables content-addressable storage of file contents: when
+	// two or more files have byte-identical uncompressed content and were
+	// assigned the same codec (see CompressionFunc), they share a single
+	// blob in the generated code instead of each embedding their own copy.
+	// Files with identical content but different codecs are stored
+	// separately, since their stored bytes differ. Defaults to false.
+	Deduplicate bool
+}
+
+// OutputFormat selects the encoding Generate uses for its output.
+type OutputFormat byte
+
+const (
+	// GoSource generates a single Go source file with the input filesystem
+	// hex-escaped directly into Go string literals.
+	GoSource OutputFormat = iota
+
+	// Zip generates a .zip archive of the input filesystem next to a tiny
+	// Go source stub that embeds and opens it via archive/zip.
+	Zip
+)
+
+// fillMissing sets default values for mandatory options that are left empty.
+func (opt *Options) fillMissing() {
+	if opt.VariableName == "" {
+		opt.VariableName = "assets"
+	}
+	if opt.Filename == "" {
+		opt.Filename = strings.ToLower(opt.VariableName) + "_vfsdata.go"
+	}
+	if opt.PackageName == "" {
+		opt.PackageName = "main"
+	}
+	if opt.VariableComment == "" {
+		opt.VariableComment = fmt.Sprintf("%s statically implements the virtual filesystem provided to vfsgen.Generate.", opt.VariableName)
+	}
+	if opt.Prefix == "" {
+		opt.Prefix = "vfsgen"
+	}
+}
+
+// codecFor returns the codec to use for the given file, consulting
+// opt.CompressionFunc first and falling back to opt.Compression.
+func (opt *Options) codecFor(path, contentType string) Codec {
+	if opt.CompressionFunc != nil {
+		return opt.CompressionFunc(path, contentType)
+	}
+	return opt.Compression
+}
+
+// emitFSInterface reports whether the io/fs.FS family of interfaces should
+// be generated, honoring the EmitFSInterface default-true toggle.
+func (opt *Options) emitFSInterface() bool {
+	return opt.EmitFSInterface == nil || *opt.EmitFSInterface
+}
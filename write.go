@@ -0,0 +1,66 @@
+package vfsgen
+
+import "io"
+
+// stringWriter is an io.Writer that writes a Go string literal representing
+// the bytes written to it.
+type stringWriter struct {
+	io.Writer
+	N int64 // Number of bytes written.
+}
+
+func (sw *stringWriter) Write(p []byte) (n int, err error) {
+	const hex = "0123456789abcdef"
+	buf := []byte(`\x00`)
+	for _, b := range p {
+		buf[2] = hex[b/16]
+		buf[3] = hex[b%16]
+		_, err = sw.Writer.Write(buf)
+		if err != nil {
+			return n, err
+		}
+		n++
+		sw.N++
+	}
+	return n, nil
+}
+
+// commentWriter writes a Go comment to the underlying io.Writer, using line
+// comment form (//).
+type commentWriter struct {
+	W            io.Writer
+	wroteSlashes bool // Wrote "//" at the beginning of the most recent line.
+}
+
+func (cw *commentWriter) Write(p []byte) (int, error) {
+	var n int
+	for _, b := range p {
+		if !cw.wroteSlashes {
+			s := "//"
+			if b != '\n' {
+				s = "// "
+			}
+			if _, err := io.WriteString(cw.W, s); err != nil {
+				return n, err
+			}
+			cw.wroteSlashes = true
+		}
+		n0, err := cw.W.Write([]byte{b})
+		n += n0
+		if err != nil {
+			return n, err
+		}
+		if b == '\n' {
+			cw.wroteSlashes = false
+		}
+	}
+	return n, nil
+}
+
+func (cw *commentWriter) Close() error {
+	if !cw.wroteSlashes {
+		return nil
+	}
+	_, err := io.WriteString(cw.W, "\n")
+	return err
+}
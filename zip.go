@@ -0,0 +1,139 @@
+package vfsgen
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"strings"
+	"text/template"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+)
+
+// notWorthGzipCompressing is the convention (shared with the GoSource
+// output's FileInfo type) used to mark a file whose contents aren't worth
+// compressing, e.g. because they're already compressed.
+type notWorthGzipCompressing interface {
+	NotWorthGzipCompressing()
+}
+
+// generateZip writes input as a .zip archive alongside a small Go source
+// stub that embeds and opens it, implementing the same http.FileSystem (and,
+// if enabled, io/fs.FS) API as the GoSource output format.
+func generateZip(input http.FileSystem, opt Options) error {
+	zipFilename := zipFilenameFor(opt.Filename)
+
+	zf, err := os.Create(zipFilename)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(zf)
+
+	walkFn := func(path string, fi os.FileInfo, r io.ReadSeeker, err error) error {
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(path, "/")
+		if fi.IsDir() {
+			if name == "" {
+				return nil
+			}
+			_, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     name + "/",
+				Modified: fi.ModTime().UTC(),
+			})
+			return err
+		}
+
+		method := zip.Deflate
+		if _, ok := fi.(notWorthGzipCompressing); ok {
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   method,
+			Modified: fi.ModTime().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, r)
+		return err
+	}
+	err = vfsutil.WalkFiles(input, "/", walkFn)
+	if err != nil {
+		zf.Close()
+		return err
+	}
+	err = zw.Close()
+	if err != nil {
+		zf.Close()
+		return err
+	}
+	err = zf.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("writing", zipFilename)
+	fmt.Println("writing", opt.Filename)
+	return ioutil.WriteFile(opt.Filename, []byte(zipStub(opt, pathpkg.Base(zipFilename))), 0644)
+}
+
+// zipFilenameFor derives the .zip archive path from the Go stub's filename.
+func zipFilenameFor(goFilename string) string {
+	return strings.TrimSuffix(goFilename, ".go") + ".zip"
+}
+
+func zipStub(opt Options, zipBaseName string) string {
+	var buf strings.Builder
+	err := zipStubTemplate.Execute(&buf, struct {
+		Options
+		ZipBaseName     string
+		EmitFSInterface bool
+	}{opt, zipBaseName, opt.emitFSInterface()})
+	if err != nil {
+		// zipStubTemplate is a fixed template exercised by
+		// TestGenerateZipOutputFormat; execution against a valid Options
+		// value should never fail.
+		panic(err)
+	}
+	return buf.String()
+}
+
+var zipStubTemplate = template.Must(template.New("zipStub").Parse(`// Code generated by vfsgen; DO NOT EDIT.
+
+{{with .BuildTags}}// +build {{.}}
+
+{{end}}package {{.PackageName}}
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"net/http"
+{{if .EmitFSInterface}}	"io/fs"
+{{end}})
+
+//go:embed {{.ZipBaseName}}
+var {{.VariableName}}Zip []byte
+
+var {{.VariableName}}Archive = func() *zip.Reader {
+	zr, err := zip.NewReader(bytes.NewReader({{.VariableName}}Zip), int64(len({{.VariableName}}Zip)))
+	if err != nil {
+		panic(err)
+	}
+	return zr
+}()
+
+// {{.VariableComment}}
+var {{.VariableName}} http.FileSystem = http.FS({{.VariableName}}Archive)
+{{if .EmitFSInterface}}
+// {{.VariableName}}FS is the io/fs.FS view of {{.VariableName}}, suitable for
+// use with template.ParseFS, embed-consuming libraries, and similar.
+var {{.VariableName}}FS fs.FS = {{.VariableName}}Archive
+{{end}}`))
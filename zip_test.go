@@ -0,0 +1,79 @@
+package vfsgen
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateZipOutputFormat drives Options.OutputFormat: Zip end to end:
+// it confirms the .zip archive and Go stub are both written, then compiles
+// and runs the stub as a standalone program, exercising the embedded
+// archive through both http.FileSystem and (since EmitFSInterface defaults
+// to true) the io/fs.FS view.
+func TestGenerateZipOutputFormat(t *testing.T) {
+	inputDir := t.TempDir()
+	content := strings.Repeat("zip me up. ", 100)
+	if err := os.WriteFile(filepath.Join(inputDir, "data.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	moduleDir := t.TempDir()
+	vfsdataPath := filepath.Join(moduleDir, "assets_vfsdata.go")
+	opt := Options{
+		Filename:     vfsdataPath,
+		PackageName:  "main",
+		VariableName: "Assets",
+		OutputFormat: Zip,
+	}
+	if err := Generate(http.Dir(inputDir), opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(vfsdataPath); err != nil {
+		t.Fatalf("Go stub wasn't written: %v", err)
+	}
+	zipPath := zipFilenameFor(vfsdataPath)
+	if _, err := os.Stat(zipPath); err != nil {
+		t.Fatalf(".zip archive wasn't written: %v", err)
+	}
+
+	harness := `package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+func main() {
+	f, err := Assets.Open("/data.txt")
+	if err != nil {
+		panic(err)
+	}
+	httpContent, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("http-open:%s\n", httpContent)
+
+	fsContent, err := fs.ReadFile(AssetsFS, "data.txt")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("iofs-readfile:%s\n", fsContent)
+}
+`
+	out := runGeneratedHarness(t, moduleDir, harness)
+	for _, want := range []string{
+		"http-open:" + content,
+		"iofs-readfile:" + content,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected generated program output to contain %q, got:\n%s", want, out)
+		}
+	}
+}